@@ -2,6 +2,8 @@ package agent
 
 import (
 	"fmt"
+	"math/rand"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -14,6 +16,14 @@ import (
 	"github.com/buildkite/agent/retry"
 )
 
+// Seed the global math/rand source per-process. Without this, every agent
+// in a fleet starts from the same default seed and computes the identical
+// jitter() sequence, which defeats the point of jittering in the first
+// place.
+func init() {
+	rand.Seed(time.Now().UnixNano() ^ int64(os.Getpid()))
+}
+
 type AgentWorkerConfig struct {
 	// Whether to set debug in the job
 	Debug bool
@@ -33,6 +43,39 @@ type AgentWorkerConfig struct {
 	// How long to remain idle before disconnecting
 	DisconnectAfterIdleTimeout int
 
+	// Whether to use a long-lived push connection to receive job assignments
+	// instead of polling Ping on a timer. Falls back to polling automatically
+	// if the endpoint doesn't support it.
+	PushMode bool
+
+	// How many consecutive keepalive failures on the push connection it
+	// takes to tear it down, after which a new connection is opened. Push
+	// mode only falls back to polling if opening a connection fails. 0
+	// defaults to 3.
+	MaxConsecutivePushKeepaliveFailures int
+
+	// How many jobs this agent will run at the same time. Defaults to 1,
+	// which preserves the traditional one-job-at-a-time behavior.
+	MaxConcurrentJobs int
+
+	// How many consecutive heartbeat failures it takes for the agent to
+	// give up and disconnect. 0 disables this check.
+	MaxConsecutiveHeartbeatFailures int
+
+	// How many consecutive ping failures it takes for the agent to give up
+	// and disconnect. 0 disables this check.
+	MaxConsecutivePingFailures int
+
+	// The ceiling (in seconds) that exponential ping backoff won't exceed,
+	// however many consecutive failures there have been. 0 defaults to 10x
+	// the agent's configured ping interval. Jitter is applied after the
+	// ceiling, so the actual wait can be up to 1.5x this value.
+	MaxPingInterval int
+
+	// The Connector used to acquire a JobRunner for an accepted job.
+	// Defaults to a LocalConnector, which runs jobs as local subprocesses.
+	Connector Connector
+
 	// The config to pass to the JobRunnerConfig
 	JobRunnerConfig JobRunnerConfig
 }
@@ -44,6 +87,10 @@ type AgentWorker struct {
 	// of the struct
 	lastPing, lastHeartbeat int64
 
+	// Tracks consecutive ping and heartbeat failures, reset to zero on
+	// success. Used to decide when we've lost contact with Buildkite.
+	consecutivePingFailures, consecutiveHeartbeatFailures int32
+
 	// The config for the AgentWorker
 	conf AgentWorkerConfig
 
@@ -65,11 +112,16 @@ type AgentWorker struct {
 	// Whether to enable debug
 	debug bool
 
-	// Whether or not the agent is running
-	running bool
+	// The agent's lifecycle state. Guarded by stateMutex; use transition()
+	// to move between states and State()/isStopping() to read it.
+	state            State
+	stateMutex       sync.Mutex
+	stateSubscribers []chan State
 
-	// Used by the Start call to control the looping of the pings
-	ticker *time.Ticker
+	// Used by the Start call to control the looping of the pings. Re-armed
+	// after every Ping() call with a jittered, possibly backed-off interval,
+	// rather than ticking on a fixed schedule.
+	pingTimer *time.Timer
 
 	// Tracking the auto disconnect timer
 	disconnectTimeoutTimer *time.Timer
@@ -79,12 +131,16 @@ type AgentWorker struct {
 
 	// Stop controls
 	stop      chan struct{}
-	stopping  bool
 	stopMutex sync.Mutex
 
-	// When this worker runs a job, we'll store an instance of the
-	// JobRunner here
-	jobRunner *JobRunner
+	// The Connector used to acquire a JobRunner for each accepted job
+	connector Connector
+
+	// The JobRunners for any jobs currently being run by this worker,
+	// keyed by job ID. Guarded by jobRunnersMutex since jobs can be
+	// accepted, run, and finish concurrently.
+	jobRunners      map[string]JobRunner
+	jobRunnersMutex sync.Mutex
 }
 
 // Creates the agent worker and initializes it's API Client
@@ -103,6 +159,11 @@ func NewAgentWorker(l logger.Logger, a *api.AgentRegisterResponse, m *metrics.Co
 		DisableHTTP2: c.DisableHTTP2,
 	})
 
+	connector := c.Connector
+	if connector == nil {
+		connector = NewLocalConnector(l, a, c.JobRunnerConfig)
+	}
+
 	return &AgentWorker{
 		logger:           l,
 		conf:             c,
@@ -111,6 +172,8 @@ func NewAgentWorker(l logger.Logger, a *api.AgentRegisterResponse, m *metrics.Co
 		apiClient:        apiClient,
 		debug:            c.Debug,
 		stop:             make(chan struct{}),
+		connector:        connector,
+		jobRunners:       make(map[string]JobRunner),
 	}
 }
 
@@ -127,27 +190,39 @@ func (a *AgentWorker) Start() error {
 	defer a.metricsCollector.Stop()
 
 	// Mark the agent as running
-	a.running = true
+	if err := a.transition(Idle); err != nil {
+		return err
+	}
 
 	// Create the intervals we'll be using
 	pingInterval := time.Second * time.Duration(a.agent.PingInterval)
 	heartbeatInterval := time.Second * time.Duration(a.agent.HeartbeatInterval)
 
-	// Create the ticker
-	a.ticker = time.NewTicker(pingInterval)
+	// Create the ping timer. Ping() re-arms it with a jittered interval
+	// after every call, and the poll loop below calls Ping() before it
+	// first waits on the timer, so this initial value is only a placeholder.
+	a.pingTimer = time.NewTimer(pingInterval)
 
 	// Setup and start the heartbeater
 	go func() {
 		for {
 			select {
-			case <-time.After(heartbeatInterval):
+			case <-time.After(jitter(heartbeatInterval)):
 				err := a.Heartbeat()
 				if err != nil {
 					// Get the last heartbeat time to the nearest microsecond
-					lastHeartbeat := time.Unix(atomic.LoadInt64(&a.lastPing), 0)
+					lastHeartbeat := time.Unix(atomic.LoadInt64(&a.lastHeartbeat), 0)
 
 					a.logger.Error("Failed to heartbeat %s. Will try again in %s. (Last successful was %v ago)",
 						err, heartbeatInterval, time.Now().Sub(lastHeartbeat))
+
+					failures := atomic.AddInt32(&a.consecutiveHeartbeatFailures, 1)
+					if a.conf.MaxConsecutiveHeartbeatFailures > 0 && int(failures) >= a.conf.MaxConsecutiveHeartbeatFailures {
+						a.disconnectDueToLostContact(fmt.Sprintf("%d consecutive failed heartbeats", failures))
+						return
+					}
+				} else {
+					atomic.StoreInt32(&a.consecutiveHeartbeatFailures, 0)
 				}
 
 			case <-a.stop:
@@ -197,27 +272,182 @@ func (a *AgentWorker) Start() error {
 		a.logger.Info("Waiting for work...")
 	}
 
-	// Continue this loop until the the ticker is stopped, and we received
+	// If push mode is enabled, try to stay on a long-lived connection and
+	// only fall back to polling if the endpoint doesn't support it
+	if a.conf.PushMode {
+		a.pushLoop()
+
+		select {
+		case <-a.stop:
+			a.pingTimer.Stop()
+
+			// Let any jobs still running finish (or, after a forced stop,
+			// be canceled) before reporting ourselves disconnected
+			for a.runningJobCount() > 0 {
+				time.Sleep(time.Second)
+			}
+
+			a.transition(Disconnected)
+			return nil
+		default:
+			a.logger.Warn("Falling back to the Ping poll loop")
+		}
+	}
+
+	// Continue this loop until the ping timer is stopped, and we received
 	// a message on the stop channel.
 	for {
-		if !a.stopping {
+		if !a.isStopping() {
 			a.Ping()
 		}
 
 		select {
-		case <-a.ticker.C:
+		case <-a.pingTimer.C:
 			continue
 		case <-a.stop:
-			a.ticker.Stop()
+			a.pingTimer.Stop()
+
+			// Let any jobs still running finish (or, after a forced stop,
+			// be canceled) before reporting ourselves disconnected
+			for a.runningJobCount() > 0 {
+				time.Sleep(time.Second)
+			}
 
 			// Mark the agent as not running anymore
-			a.running = false
+			a.transition(Disconnected)
 
 			return nil
 		}
 	}
 }
 
+// pushLoop keeps a long-lived connection open to the Buildkite API and
+// handles job assignments, disconnect actions, and endpoint switches as
+// they're pushed down, rather than polling for them. It keeps the
+// connection alive with application-level keepalive frames, and opens a new
+// one whenever it's lost or torn down after too many consecutive failed
+// keepalives. It gives up (returning to the caller, which falls back to the
+// poll loop) if the endpoint doesn't support push mode, or if a connection
+// can't be opened after retrying.
+func (a *AgentWorker) pushLoop() {
+	for {
+		select {
+		case <-a.stop:
+			return
+		default:
+		}
+
+		var conn api.PushConnection
+		err := retry.Do(func(s *retry.Stats) error {
+			var err error
+			conn, err = a.apiClient.Pings.Connect()
+			if err != nil {
+				a.logger.Warn("Failed to open push connection: %s (%s)", err, s)
+			}
+			return err
+		}, &retry.Config{Maximum: 10, Interval: 5 * time.Second})
+
+		if err != nil {
+			if err == api.ErrPushModeUnsupported {
+				a.logger.Warn("Push mode isn't supported by this endpoint")
+				return
+			}
+
+			a.logger.Warn("Giving up opening a push connection: %s", err)
+			return
+		}
+
+		disconnected := a.handlePushConnection(conn)
+
+		if disconnected {
+			return
+		}
+
+		select {
+		case <-a.stop:
+			return
+		default:
+			a.logger.Warn("Push connection was lost, reconnecting...")
+		}
+	}
+}
+
+// handlePushConnection reads messages off an open push connection until it's
+// closed, a disconnect action is received, or keepalives start failing.
+// Returns true if the agent should stop entirely (rather than reconnect).
+func (a *AgentWorker) handlePushConnection(conn api.PushConnection) bool {
+	defer conn.Close()
+
+	keepaliveFailures := 0
+	maxKeepaliveFailures := a.conf.MaxConsecutivePushKeepaliveFailures
+	if maxKeepaliveFailures == 0 {
+		maxKeepaliveFailures = 3
+	}
+
+	keepalive := time.NewTicker(10 * time.Second)
+	defer keepalive.Stop()
+
+	// done tells the reader goroutine below to give up on delivering
+	// whatever it's got, no matter why we're returning (a.stop firing,
+	// too many keepalive failures, a disconnect action). Without it, the
+	// reader can be left parked forever on a send nobody's left to
+	// receive, once we've already returned.
+	done := make(chan struct{})
+	defer close(done)
+
+	messages := make(chan api.Ping, 1)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			ping, err := conn.Recv()
+			if err != nil {
+				select {
+				case errs <- err:
+				case <-done:
+				}
+				return
+			}
+
+			select {
+			case messages <- *ping:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-a.stop:
+			return true
+
+		case <-keepalive.C:
+			if err := conn.Keepalive(); err != nil {
+				keepaliveFailures++
+				a.logger.Warn("Push connection keepalive failed (%d/%d): %s", keepaliveFailures, maxKeepaliveFailures, err)
+
+				if keepaliveFailures >= maxKeepaliveFailures {
+					a.logger.Error("Push connection stopped responding to keepalives, tearing it down")
+					return false
+				}
+			} else {
+				keepaliveFailures = 0
+			}
+
+		case err := <-errs:
+			a.logger.Warn("Push connection closed: %s", err)
+			return false
+
+		case ping := <-messages:
+			keepaliveFailures = 0
+			if a.handlePing(&ping) {
+				return true
+			}
+		}
+	}
+}
+
 // Stops the agent from accepting new work and cancels any current work it's
 // running
 func (a *AgentWorker) Stop(graceful bool) {
@@ -226,27 +456,41 @@ func (a *AgentWorker) Stop(graceful bool) {
 	a.stopMutex.Lock()
 	defer a.stopMutex.Unlock()
 
+	// Hold jobRunnersMutex for the whole decision, not just the cancel
+	// loop below, and all the way through the Draining/Disconnecting
+	// transitions. registerJobRunner takes the same lock around its
+	// isStopping() check, so a job can't sneak in (and be silently
+	// orphaned, running but never canceled) in the window between us
+	// deciding to stop and actually becoming isStopping().
+	a.jobRunnersMutex.Lock()
+	defer a.jobRunnersMutex.Unlock()
+
+	running := len(a.jobRunners)
+	alreadyStopping := a.isStopping()
+
 	if graceful {
-		if a.stopping {
+		if alreadyStopping {
 			a.logger.Warn("Agent is already gracefully stopping...")
 		} else {
-			// If we have a job, tell the user that we'll wait for
-			// it to finish before disconnecting
-			if a.jobRunner != nil {
-				a.logger.Info("Gracefully stopping agent. Waiting for current job to finish before disconnecting...")
+			// If we have any jobs running, tell the user that we'll
+			// wait for them to finish before disconnecting
+			if running > 0 {
+				a.logger.Info("Gracefully stopping agent. Waiting for %d running job(s) to finish before disconnecting...", running)
 			} else {
 				a.logger.Info("Gracefully stopping agent. Since there is no job running, the agent will disconnect immediately")
 			}
 		}
 	} else {
-		// If there's a job running, kill it, then disconnect
-		if a.jobRunner != nil {
-			a.logger.Info("Forcefully stopping agent. The current job will be canceled before disconnecting...")
+		// If there are jobs running, kill them, then disconnect
+		if running > 0 {
+			a.logger.Info("Forcefully stopping agent. %d running job(s) will be canceled before disconnecting...", running)
 
-			// Kill the current job. Doesn't do anything if the job
+			// Kill every running job. Doesn't do anything if a job
 			// is already being killed, so it's safe to call
 			// multiple times.
-			a.jobRunner.Cancel()
+			for _, runner := range a.jobRunners {
+				runner.Cancel()
+			}
 		} else {
 			a.logger.Info("Forcefully stopping agent. Since there is no job running, the agent will disconnect immediately")
 		}
@@ -254,29 +498,90 @@ func (a *AgentWorker) Stop(graceful bool) {
 
 	// We don't need to do the below operations again since we've already
 	// done them before
-	if a.stopping {
+	if alreadyStopping {
 		return
 	}
 
-	// Update the proc title
-	a.UpdateProcTitle("stopping")
+	// Jobs that are still running keep going in their own goroutines even
+	// after this point, and Start waits for them before it returns. Draining
+	// marks that jobs were running when the stop began; it's followed
+	// straight away by Disconnecting, which lasts through that wait.
+	if running > 0 {
+		a.transition(Draining)
+	}
+	a.transition(Disconnecting)
 
-	// If we have a ticker, stop it, and send a signal to the stop channel,
-	// which will cause the agent worker to stop looping immediatly.
+	// Stop the ping timer, and send a signal to the stop channel, which
+	// will cause the agent worker to stop looping immediatly.
+	a.pingTimer.Stop()
 	close(a.stop)
-
-	// Mark the agent as stopping
-	a.stopping = true
 }
 
 func (a *AgentWorker) stopIfIdle() {
-	if a.jobRunner == nil && !a.stopping {
+	if a.runningJobCount() == 0 && !a.isStopping() {
 		a.Stop(true)
 	} else {
 		a.logger.Debug("Agent is running a job, going to let it finish it's work")
 	}
 }
 
+// maxConcurrentJobs returns the number of jobs this agent is allowed to run
+// at once, defaulting to 1 (the traditional one-job-at-a-time behavior).
+func (a *AgentWorker) maxConcurrentJobs() int {
+	if a.conf.MaxConcurrentJobs <= 0 {
+		return 1
+	}
+	return a.conf.MaxConcurrentJobs
+}
+
+// hasFreeJobSlot reports whether the agent has room to accept another job,
+// used for admission control before we Accept a job that was assigned to us.
+func (a *AgentWorker) hasFreeJobSlot() bool {
+	return a.runningJobCount() < a.maxConcurrentJobs()
+}
+
+func (a *AgentWorker) runningJobCount() int {
+	a.jobRunnersMutex.Lock()
+	defer a.jobRunnersMutex.Unlock()
+
+	return len(a.jobRunners)
+}
+
+// registerJobRunner registers a running job's JobRunner and updates the
+// slot utilization metric, unless the agent is already stopping. The
+// isStopping() check happens under the same lock Stop() holds across its
+// Draining/Disconnecting transitions, so the two can't race: either we see
+// isStopping() and refuse to register (leaving the caller to cancel the
+// runner without ever starting it), or Stop() blocks until we're done and
+// then cancels what we just registered.
+func (a *AgentWorker) registerJobRunner(jobID string, runner JobRunner) bool {
+	a.jobRunnersMutex.Lock()
+	defer a.jobRunnersMutex.Unlock()
+
+	if a.isStopping() {
+		return false
+	}
+
+	a.jobRunners[jobID] = runner
+	running := len(a.jobRunners)
+
+	a.metrics.Gauge("jobs.running", float64(running))
+	a.metrics.Gauge("jobs.slots", float64(a.maxConcurrentJobs()))
+	return true
+}
+
+// removeJobRunner deregisters a finished job's JobRunner, updates the slot
+// utilization metric, and returns the number of jobs still running.
+func (a *AgentWorker) removeJobRunner(jobID string) int {
+	a.jobRunnersMutex.Lock()
+	delete(a.jobRunners, jobID)
+	running := len(a.jobRunners)
+	a.jobRunnersMutex.Unlock()
+
+	a.metrics.Gauge("jobs.running", float64(running))
+	return running
+}
+
 // Connects the agent to the Buildkite Agent API, retrying up to 30 times if it
 // fails.
 func (a *AgentWorker) Connect() error {
@@ -322,8 +627,7 @@ func (a *AgentWorker) Heartbeat() error {
 
 // Performs a ping, which returns what action the agent should take next.
 func (a *AgentWorker) Ping() {
-	// Update the proc title
-	a.UpdateProcTitle("pinging")
+	a.transition(Pinging)
 
 	ping, _, err := a.apiClient.Pings.Get()
 	if err != nil {
@@ -344,12 +648,81 @@ func (a *AgentWorker) Ping() {
 			a.logger.Debug("[DisconnectionTimer] Reset back to %d seconds because of ping failure...", a.conf.DisconnectAfterJobTimeout)
 		}
 
+		failures := atomic.AddInt32(&a.consecutivePingFailures, 1)
+		if a.conf.MaxConsecutivePingFailures > 0 && int(failures) >= a.conf.MaxConsecutivePingFailures {
+			a.disconnectDueToLostContact(fmt.Sprintf("%d consecutive failed pings", failures))
+		}
+
+		a.rearmPingTimer(nil)
 		return
 	} else {
 		// Track a timestamp for the successful ping for better errors
 		atomic.StoreInt64(&a.lastPing, time.Now().Unix())
+		atomic.StoreInt32(&a.consecutivePingFailures, 0)
 	}
 
+	a.rearmPingTimer(ping)
+	a.handlePing(ping)
+}
+
+// rearmPingTimer resets the ping timer for the next Ping() call, using full
+// jitter so a fleet of agents registered together doesn't end up
+// synchronized, exponential backoff on consecutive failures (reset on the
+// next success), and a server-provided ping.NextInterval hint when we have
+// one.
+func (a *AgentWorker) rearmPingTimer(ping *api.Ping) {
+	interval := time.Second * time.Duration(a.agent.PingInterval)
+
+	if ping != nil && ping.NextInterval > 0 {
+		interval = time.Second * time.Duration(ping.NextInterval)
+	}
+
+	if failures := atomic.LoadInt32(&a.consecutivePingFailures); failures > 0 {
+		ceiling := time.Second * time.Duration(a.conf.MaxPingInterval)
+		if ceiling <= 0 {
+			ceiling = interval * 10
+		}
+
+		backoff := interval
+		for i := int32(0); i < failures && backoff < ceiling; i++ {
+			backoff *= 2
+		}
+		if backoff > ceiling {
+			backoff = ceiling
+		}
+
+		interval = backoff
+	}
+
+	interval = jitter(interval)
+
+	a.logger.Debug("Next ping in %s", interval)
+	a.pingTimer.Reset(interval)
+}
+
+// jitter returns a duration picked uniformly from [d/2, d*3/2), so that a
+// fleet of agents with the same configured interval don't all hit the API
+// at once.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// disconnectDueToLostContact stops the agent because we've given up trying
+// to reach Buildkite, as distinct from a user- or server-initiated stop.
+func (a *AgentWorker) disconnectDueToLostContact(reason string) {
+	a.logger.Error("Lost contact with Buildkite (%s), disconnecting...", reason)
+	a.Stop(true)
+}
+
+// handlePing processes a ping response, whether it arrived via a polled
+// Ping() call or was pushed down an open push connection. Returns true if
+// the agent is disconnecting and the caller should stop processing further
+// messages.
+func (a *AgentWorker) handlePing(ping *api.Ping) bool {
 	// Should we switch endpoints?
 	if ping.Endpoint != "" && ping.Endpoint != a.agent.Endpoint {
 		// Before switching to the new one, do a ping test to make sure it's
@@ -379,17 +752,27 @@ func (a *AgentWorker) Ping() {
 	// Should the agent disconnect?
 	if ping.Action == "disconnect" {
 		a.Stop(false)
-		return
+		return true
 	}
 
 	// If we don't have a job, there's nothing to do!
 	if ping.Job == nil {
-		// Update the proc title
-		a.UpdateProcTitle("idle")
+		a.transition(a.idleOrRunningState())
 
-		return
+		return false
 	}
 
+	// Admission control: only accept the job if we actually have a free
+	// slot to run it in. If we don't, leave it for another agent to pick
+	// up on its next ping.
+	if !a.hasFreeJobSlot() {
+		a.logger.Debug("Assigned job %s but all %d job slot(s) are in use, skipping", ping.Job.ID, a.maxConcurrentJobs())
+		a.transition(a.idleOrRunningState())
+		return false
+	}
+
+	a.transition(Accepting)
+
 	// Update the proc title
 	a.UpdateProcTitle(fmt.Sprintf("job %s", strings.Split(ping.Job.ID, "-")[0]))
 
@@ -399,6 +782,7 @@ func (a *AgentWorker) Ping() {
 	// Buildkite returns a 422 or 500 for example, we'll just bail out,
 	// re-ping, and try the whole process again.
 	var accepted *api.Job
+	var err error
 	retry.Do(func(s *retry.Stats) error {
 		accepted, _, err = a.apiClient.Jobs.Accept(ping.Job)
 
@@ -417,7 +801,8 @@ func (a *AgentWorker) Ping() {
 	// If `accepted` is nil, then the job was never accepted
 	if accepted == nil {
 		a.logger.Error("Failed to accept job")
-		return
+		a.transition(a.idleOrRunningState())
+		return false
 	}
 
 	jobMetricsScope := a.metrics.With(metrics.Tags{
@@ -427,45 +812,68 @@ func (a *AgentWorker) Ping() {
 		`source`:   accepted.Env[`BUILDKITE_SOURCE`],
 	})
 
-	// Now that the job has been accepted, we can start it.
-	a.jobRunner, err = NewJobRunner(a.logger, jobMetricsScope, a.agent, accepted, a.conf.JobRunnerConfig)
-
-	// Woo! We've got a job, and successfully accepted it, let's kill our auto-disconnect timer
-	if a.disconnectTimeoutTimer != nil {
-		a.logger.Debug("[DisconnectionTimer] A job was assigned and accepted, stopping timer...")
-		a.disconnectTimeoutTimer.Stop()
-	}
+	// Now that the job has been accepted, ask our Connector to acquire a
+	// JobRunner for it (by default, a local subprocess).
+	jobRunner, err := a.connector.Acquire(jobMetricsScope, accepted)
 
 	// Was there an error creating the job runner?
 	if err != nil {
 		a.logger.Error("Failed to initialize job: %s", err)
-		return
+		a.transition(a.idleOrRunningState())
+		return false
+	}
+
+	// Stop() may have started tearing us down while we were busy accepting
+	// the job and acquiring a runner for it. If so, don't start it: it
+	// would otherwise run unsupervised, orphaned from a.jobRunners and
+	// never canceled.
+	if !a.registerJobRunner(accepted.ID, jobRunner) {
+		a.logger.Warn("Accepted job %s, but the agent is disconnecting - canceling it without running", accepted.ID)
+		jobRunner.Cancel()
+		return true
 	}
 
-	// Start running the job
-	if err = a.jobRunner.Run(); err != nil {
+	a.transition(Running)
+
+	// Run the job in its own goroutine so we're free to accept more jobs
+	// (up to our slot limit) while it's still going.
+	go a.runJob(accepted.ID, jobRunner)
+
+	return false
+}
+
+// runJob runs a single job to completion and cleans up after it, firing the
+// disconnect-after-job/idle-timeout behavior once the agent is idle again
+// (i.e. once every job slot is free).
+func (a *AgentWorker) runJob(jobID string, runner JobRunner) {
+	if err := runner.Run(); err != nil {
 		a.logger.Error("Failed to run job: %s", err)
 	}
 
-	// No more job, no more runner.
-	a.jobRunner = nil
+	// If there are still other jobs running, leave the disconnect/idle
+	// handling to whichever one finishes last.
+	if a.removeJobRunner(jobID) > 0 {
+		return
+	}
 
 	if a.conf.DisconnectAfterJob {
 		a.logger.Info("Job finished. Disconnecting...")
-
-		// We can just kill this timer now as well
-		if a.disconnectTimeoutTimer != nil {
-			a.disconnectTimeoutTimer.Stop()
-		}
-
-		// Tell the agent to finish up
 		a.Stop(true)
+		return
 	}
 
-	if a.conf.DisconnectAfterIdleTimeout > 0 {
-		a.logger.Info("Job finished. Resetting idle timer...")
+	// Back to idle. Reset the idle-disconnect timer here, now that the
+	// agent is actually idle, rather than in the Idle state's side
+	// effect: every job-less ping also transitions through Idle, and
+	// PingInterval is far shorter than DisconnectAfterIdleTimeout, so
+	// resetting it there would re-arm the timer on every single ping and
+	// stopIfIdle would never fire.
+	if a.idleTimer != nil {
 		a.idleTimer.Reset(time.Second * time.Duration(a.conf.DisconnectAfterIdleTimeout))
 	}
+
+	a.logger.Debug("Job finished. Agent is idle again.")
+	a.transition(Idle)
 }
 
 // Disconnects the agent from the Buildkite Agent API, doesn't bother retrying
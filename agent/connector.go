@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"github.com/buildkite/agent/api"
+	"github.com/buildkite/agent/logger"
+	"github.com/buildkite/agent/metrics"
+)
+
+// JobRunner is the interface a Connector hands back for a single job. The
+// concrete, local-subprocess implementation lives in JobRunner's own file;
+// this interface is what lets AgentWorker stay agnostic to how (or where)
+// the job actually runs.
+type JobRunner interface {
+	Run() error
+	Cancel() error
+}
+
+// Connector acquires a JobRunner capable of executing a given job, modeled
+// on provisionerd's Connector pattern. Implementations can choose how and
+// where the job actually runs (locally, in a container, on a remote host,
+// and so on) based on the job itself, letting an agent support multiple
+// execution backends without forking AgentWorker's loop.
+type Connector interface {
+	Acquire(scope *metrics.Scope, job *api.Job) (JobRunner, error)
+}
+
+// LocalConnector is the default Connector. It preserves the agent's
+// original behavior of running every job as a local subprocess.
+type LocalConnector struct {
+	logger logger.Logger
+	agent  *api.AgentRegisterResponse
+	conf   JobRunnerConfig
+}
+
+// Creates a LocalConnector
+func NewLocalConnector(l logger.Logger, a *api.AgentRegisterResponse, c JobRunnerConfig) *LocalConnector {
+	return &LocalConnector{logger: l, agent: a, conf: c}
+}
+
+func (c *LocalConnector) Acquire(scope *metrics.Scope, job *api.Job) (JobRunner, error) {
+	return NewJobRunner(c.logger, scope, c.agent, job, c.conf)
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"fmt"
+)
+
+// State is a point in an AgentWorker's lifecycle. AgentWorker used to thread
+// this across a handful of booleans, two timers, a ticker, and a stop
+// channel; having it explicit means Stop, stopIfIdle, and endpoint-switching
+// during Ping can't disagree about what the agent is currently doing.
+type State int
+
+const (
+	// Disconnected is the state before Start is called, and again once the
+	// agent has fully shut down. It's State's zero value.
+	Disconnected State = iota
+	Idle
+	Pinging
+	Accepting
+	Running
+	Draining
+	Disconnecting
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Idle:
+		return "idle"
+	case Pinging:
+		return "pinging"
+	case Accepting:
+		return "accepting"
+	case Running:
+		return "running"
+	case Draining:
+		return "draining"
+	case Disconnecting:
+		return "disconnecting"
+	default:
+		return "unknown"
+	}
+}
+
+// legalStateTransitions enumerates which states can follow which. Anything
+// not listed here is rejected by transition().
+var legalStateTransitions = map[State][]State{
+	Disconnected: {Idle},
+	// Accepting/Running are reachable directly from Idle because in push
+	// mode, handlePing is called from handlePushConnection with no
+	// preceding transition(Pinging) - a job can be pushed down at any
+	// time, not just in response to our own poll.
+	Idle: {Pinging, Accepting, Running, Draining, Disconnecting},
+	// Running is reachable from Pinging directly because jobs run in their
+	// own goroutines and the poll loop keeps pinging meanwhile, so a ping
+	// can come back empty-handed (or get rejected by admission control)
+	// while other jobs are still in flight.
+	Pinging:       {Idle, Running, Accepting, Draining, Disconnecting},
+	Accepting:     {Running, Idle, Draining, Disconnecting},
+	Running:       {Idle, Pinging, Accepting, Draining, Disconnecting},
+	Draining:      {Disconnecting},
+	Disconnecting: {Disconnected},
+}
+
+// transition moves the agent to next, rejecting illegal transitions, and
+// performs the side effects (proc title updates, stopping the auto-disconnect
+// timer) that used to
+// be scattered across Stop, Ping, and stopIfIdle.
+func (a *AgentWorker) transition(next State) error {
+	a.stateMutex.Lock()
+
+	current := a.state
+	if current == next {
+		a.stateMutex.Unlock()
+		return nil
+	}
+
+	allowed := false
+	for _, s := range legalStateTransitions[current] {
+		if s == next {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		a.stateMutex.Unlock()
+		return fmt.Errorf("illegal agent state transition from %s to %s", current, next)
+	}
+
+	a.state = next
+	subscribers := append([]chan State{}, a.stateSubscribers...)
+	a.stateMutex.Unlock()
+
+	switch next {
+	case Idle:
+		a.UpdateProcTitle("idle")
+	case Pinging:
+		a.UpdateProcTitle("pinging")
+	case Running:
+		// Only stop the auto-disconnect timer once a job is actually
+		// running, not when we start trying to accept one: if the
+		// accept or connector.Acquire call fails with no other job
+		// running, we fall back to Idle without ever reaching here, so
+		// the timer is still armed and DisconnectAfterJob still works.
+		if a.disconnectTimeoutTimer != nil {
+			a.disconnectTimeoutTimer.Stop()
+		}
+		// The poll loop keeps transitioning through Pinging (which sets
+		// the "pinging" title) for as long as a job is running, so set
+		// the title back here on every entry into Running, not just the
+		// first.
+		a.UpdateProcTitle(fmt.Sprintf("running %d job(s)", a.runningJobCount()))
+	case Draining, Disconnecting:
+		a.UpdateProcTitle("stopping")
+	case Disconnected:
+		a.UpdateProcTitle("disconnected")
+	}
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- next:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// State returns the agent's current lifecycle state.
+func (a *AgentWorker) State() State {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+
+	return a.state
+}
+
+// idleOrRunningState reports the state the agent should be in when it isn't
+// actively pinging or accepting: Running if it still has jobs in flight
+// (the poll loop keeps pinging while jobs run), Idle otherwise.
+func (a *AgentWorker) idleOrRunningState() State {
+	if a.runningJobCount() > 0 {
+		return Running
+	}
+	return Idle
+}
+
+// isStopping reports whether the agent is in the process of shutting down.
+func (a *AgentWorker) isStopping() bool {
+	switch a.State() {
+	case Draining, Disconnecting, Disconnected:
+		return true
+	default:
+		return false
+	}
+}
+
+// Subscribe registers a channel to receive the agent's state transitions, so
+// tests and metrics can observe them deterministically instead of polling.
+// Sends are non-blocking; a slow subscriber misses transitions rather than
+// stalling the agent.
+func (a *AgentWorker) Subscribe(ch chan State) {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+
+	a.stateSubscribers = append(a.stateSubscribers, ch)
+}
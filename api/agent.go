@@ -0,0 +1,29 @@
+package api
+
+// AgentRegisterResponse is the agent record returned when an agent
+// registers with Buildkite.
+type AgentRegisterResponse struct {
+	UUID              string
+	Name              string
+	AccessToken       string
+	Endpoint          string
+	PingInterval      int
+	HeartbeatInterval int
+}
+
+// AgentsService handles communication with the agent related endpoints.
+type AgentsService struct {
+	client *Client
+}
+
+// Connect sends no request to Buildkite: it returns a nil Response and a
+// nil error.
+func (a *AgentsService) Connect() (*Response, error) {
+	return nil, nil
+}
+
+// Disconnect sends no request to Buildkite: it returns a nil Response and
+// a nil error.
+func (a *AgentsService) Disconnect() (*Response, error) {
+	return nil, nil
+}
@@ -0,0 +1,21 @@
+package api
+
+import "net/http"
+
+// Client is the Buildkite Agent API client. It's obtained indirectly via
+// agent.NewAPIClient, which wraps it with the endpoint/token/HTTP2 config
+// supplied in an agent.APIClientConfig.
+type Client struct {
+	Agents     *AgentsService
+	Heartbeats *HeartbeatsService
+	Jobs       *JobsService
+	Pings      *PingsService
+
+	httpClient *http.Client
+}
+
+// Response wraps the raw *http.Response returned alongside the decoded
+// result of most API calls.
+type Response struct {
+	*http.Response
+}
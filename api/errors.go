@@ -0,0 +1,18 @@
+package api
+
+import "net"
+
+// IsRetryableError reports whether err is transient (a connection or
+// timeout problem) and therefore worth retrying, as opposed to a
+// definitive rejection (e.g. a 422 or 500) from Buildkite.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,19 @@
+package api
+
+// Heartbeat represents a heartbeat from the API.
+type Heartbeat struct {
+	SentAt     string `json:"sent_at,omitempty"`
+	ReceivedAt string `json:"received_at,omitempty"`
+}
+
+// HeartbeatsService handles communication with the heartbeat related
+// endpoints.
+type HeartbeatsService struct {
+	client *Client
+}
+
+// Beat sends no request to Buildkite: it returns a nil Heartbeat, a nil
+// Response and a nil error.
+func (h *HeartbeatsService) Beat() (*Heartbeat, *Response, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,18 @@
+package api
+
+// Job represents a Buildkite Agent API Job
+type Job struct {
+	ID  string            `json:"id,omitempty"`
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// JobsService handles communication with the job related endpoints.
+type JobsService struct {
+	client *Client
+}
+
+// Accept sends no request to Buildkite: it returns a nil Job, a nil
+// Response and a nil error.
+func (j *JobsService) Accept(job *Job) (*Job, *Response, error) {
+	return nil, nil, nil
+}
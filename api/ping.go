@@ -0,0 +1,58 @@
+package api
+
+import "errors"
+
+// Ping is returned by PingsService.Get, or delivered down an open
+// PushConnection, and tells the agent what to do next: accept a job,
+// switch endpoints, disconnect, or just keep waiting.
+type Ping struct {
+	Action   string `json:"action,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Job      *Job   `json:"job,omitempty"`
+
+	// NextInterval, when set, overrides the agent's configured ping
+	// interval for the next polled ping, letting Buildkite slow a fleet
+	// down under load. Push-mode keepalives don't use it.
+	NextInterval int `json:"next_interval,omitempty"`
+}
+
+// ErrPushModeUnsupported is returned by PingsService.Connect when the
+// endpoint doesn't speak push mode, so the caller can fall back to
+// polling PingsService.Get instead.
+var ErrPushModeUnsupported = errors.New("api: push mode is not supported by this endpoint")
+
+// PushConnection is the long-lived, bidirectional connection used in push
+// mode: Buildkite sends Pings down it as job assignments, disconnect
+// actions, or endpoint switches arrive, instead of the agent polling
+// PingsService.Get on a timer.
+type PushConnection interface {
+	// Recv blocks until the next Ping arrives, or returns an error once
+	// the connection is closed or lost.
+	Recv() (*Ping, error)
+
+	// Keepalive sends an application-level keepalive frame and waits for
+	// it to be acknowledged, so a dead connection is detected even while
+	// no Pings are being pushed down it.
+	Keepalive() error
+
+	// Close tears down the connection.
+	Close() error
+}
+
+// PingsService handles communication with the ping related endpoints.
+type PingsService struct {
+	client *Client
+}
+
+// Get sends no request to Buildkite: it returns a nil Ping, a nil Response
+// and a nil error.
+func (p *PingsService) Get() (*Ping, *Response, error) {
+	return nil, nil, nil
+}
+
+// Connect opens no connection: it always returns ErrPushModeUnsupported,
+// so push mode always falls back to polling Get.
+func (p *PingsService) Connect() (PushConnection, error) {
+	return nil, ErrPushModeUnsupported
+}